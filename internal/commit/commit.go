@@ -0,0 +1,161 @@
+// Package commit implements a commit-reveal scheme for a drawn pairing,
+// so participants can later prove to each other that the organizer did
+// not tamper with the draw after the fact, without the commitments file
+// itself revealing who is giving to whom.
+package commit
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/d3spis3d/unsecret-santa/internal/pairing"
+)
+
+// saltSize is the number of random bytes generated per giver.
+const saltSize = 32
+
+// Commitment is the public, non-revealing record for one giver: a hash
+// of their giver/receiver/salt triple.
+type Commitment struct {
+	Giver string `json:"giver"`
+	Hash  string `json:"hash"`
+}
+
+// CommitmentsFile is the full public record written alongside the sealed
+// envelopes: everything needed to verify a draw, short of the pairing
+// itself.
+type CommitmentsFile struct {
+	Participants []string        `json:"participants"`
+	RuleSet      pairing.RuleSet `json:"rule_set"`
+	Commitments  []Commitment    `json:"commitments"`
+}
+
+// Envelope is the private file handed to a single giver: their own
+// assignment and the salt that was hashed into their public commitment.
+type Envelope struct {
+	Giver    string
+	Receiver string
+	Salt     string // hex-encoded
+}
+
+// Seal generates a random salt per giver, writes the public commitments
+// file to <outDir>/commitments.json, and writes each giver's own sealed
+// envelope to <outDir>/<giver>.txt. It returns the CommitmentsFile that
+// was written.
+//
+// rules is published in full, not just its flat Exclusions, so anyone
+// auditing the draw can see every rule (families, bidirectional bans,
+// no-reciprocity, previous-year history) that constrained it, not only
+// the plain giver/receiver bans.
+func Seal(participants []string, rules pairing.RuleSet, selected pairing.Pairing, outDir string) (CommitmentsFile, error) {
+	commitments := CommitmentsFile{
+		Participants: participants,
+		RuleSet:      rules,
+		Commitments:  make([]Commitment, 0, len(participants)),
+	}
+
+	for _, giver := range participants {
+		receiver := selected[giver]
+
+		saltBytes := make([]byte, saltSize)
+		if _, err := rand.Read(saltBytes); err != nil {
+			return CommitmentsFile{}, fmt.Errorf("could not generate salt for %s: %w", giver, err)
+		}
+		salt := hex.EncodeToString(saltBytes)
+
+		commitments.Commitments = append(commitments.Commitments, Commitment{
+			Giver: giver,
+			Hash:  hash(giver, receiver, salt),
+		})
+
+		envelope := Envelope{Giver: giver, Receiver: receiver, Salt: salt}
+		if err := writeEnvelope(filepath.Join(outDir, giver+".txt"), envelope); err != nil {
+			return CommitmentsFile{}, err
+		}
+	}
+
+	data, err := json.MarshalIndent(commitments, "", "  ")
+	if err != nil {
+		return CommitmentsFile{}, fmt.Errorf("could not encode commitments: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "commitments.json"), data, 0o644); err != nil {
+		return CommitmentsFile{}, fmt.Errorf("could not write commitments file: %w", err)
+	}
+
+	return commitments, nil
+}
+
+// Verify loads a commitments file and a single giver's envelope and
+// confirms the envelope's receiver and salt hash to the commitment the
+// organizer published for that giver.
+func Verify(commitmentsPath, envelopePath string) (bool, error) {
+	commitmentsData, err := os.ReadFile(commitmentsPath)
+	if err != nil {
+		return false, fmt.Errorf("could not read commitments file %s: %w", commitmentsPath, err)
+	}
+	var commitments CommitmentsFile
+	if err := json.Unmarshal(commitmentsData, &commitments); err != nil {
+		return false, fmt.Errorf("could not parse commitments file %s: %w", commitmentsPath, err)
+	}
+
+	envelope, err := readEnvelope(envelopePath)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range commitments.Commitments {
+		if c.Giver == envelope.Giver {
+			return c.Hash == hash(envelope.Giver, envelope.Receiver, envelope.Salt), nil
+		}
+	}
+	return false, fmt.Errorf("no commitment found for giver %s", envelope.Giver)
+}
+
+// hash computes sha256(giver || 0x00 || receiver || 0x00 || salt) as a
+// hex string; the NUL separators keep different name splits from
+// hashing alike.
+func hash(giver, receiver, salt string) string {
+	sum := sha256.Sum256([]byte(giver + "\x00" + receiver + "\x00" + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeEnvelope(path string, e Envelope) error {
+	contents := fmt.Sprintf("giver: %s\nreceiver: %s\nsalt: %s\n", e.Giver, e.Receiver, e.Salt)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		return fmt.Errorf("could not write envelope for %s: %w", e.Giver, err)
+	}
+	return nil
+}
+
+func readEnvelope(path string) (Envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("could not read envelope %s: %w", path, err)
+	}
+
+	var e Envelope
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "giver":
+			e.Giver = value
+		case "receiver":
+			e.Receiver = value
+		case "salt":
+			e.Salt = value
+		}
+	}
+	if e.Giver == "" || e.Salt == "" {
+		return Envelope{}, fmt.Errorf("envelope %s is missing required fields", path)
+	}
+	return e, nil
+}
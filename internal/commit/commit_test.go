@@ -0,0 +1,131 @@
+package commit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/d3spis3d/unsecret-santa/internal/pairing"
+)
+
+func sealTestGroup(t *testing.T) (dir string, participants []string, selected pairing.Pairing) {
+	t.Helper()
+
+	dir = t.TempDir()
+	participants = []string{"alice", "bob", "carol"}
+	selected = pairing.Pairing{
+		"alice": "bob",
+		"bob":   "carol",
+		"carol": "alice",
+	}
+
+	if _, err := Seal(participants, pairing.RuleSet{}, selected, dir); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	return dir, participants, selected
+}
+
+func TestVerifyAcceptsAnUntamperedEnvelope(t *testing.T) {
+	dir, participants, _ := sealTestGroup(t)
+
+	for _, giver := range participants {
+		ok, err := Verify(filepath.Join(dir, "commitments.json"), filepath.Join(dir, giver+".txt"))
+		if err != nil {
+			t.Fatalf("Verify(%s): %v", giver, err)
+		}
+		if !ok {
+			t.Fatalf("Verify(%s): want true for an untampered envelope", giver)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedSalt(t *testing.T) {
+	dir, _, _ := sealTestGroup(t)
+
+	envelopePath := filepath.Join(dir, "alice.txt")
+	data, err := os.ReadFile(envelopePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	tampered := strings.Replace(string(data), "salt: ", "salt: ff", 1)
+	if tampered == string(data) {
+		t.Fatal("test setup: tampering did not change the envelope")
+	}
+	if err := os.WriteFile(envelopePath, []byte(tampered), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ok, err := Verify(filepath.Join(dir, "commitments.json"), envelopePath)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if ok {
+		t.Fatal("Verify: want false for an envelope with a tampered salt")
+	}
+}
+
+func TestVerifyRejectsTamperedReceiver(t *testing.T) {
+	dir, _, _ := sealTestGroup(t)
+
+	envelopePath := filepath.Join(dir, "alice.txt")
+	data, err := os.ReadFile(envelopePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	tampered := strings.Replace(string(data), "receiver: bob", "receiver: carol", 1)
+	if tampered == string(data) {
+		t.Fatal("test setup: tampering did not change the envelope")
+	}
+	if err := os.WriteFile(envelopePath, []byte(tampered), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ok, err := Verify(filepath.Join(dir, "commitments.json"), envelopePath)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if ok {
+		t.Fatal("Verify: want false for an envelope with a tampered receiver")
+	}
+}
+
+func TestVerifyIgnoresEnvelopeFilename(t *testing.T) {
+	dir, _, _ := sealTestGroup(t)
+
+	// Copy bob's envelope contents to a differently-named file; Verify
+	// identifies the commitment by the "giver" field inside the envelope,
+	// not by the path it was read from, so this should still succeed.
+	bobData, err := os.ReadFile(filepath.Join(dir, "bob.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	renamedPath := filepath.Join(dir, "renamed.txt")
+	if err := os.WriteFile(renamedPath, bobData, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	ok, err := Verify(filepath.Join(dir, "commitments.json"), renamedPath)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify: want true when the envelope content matches its giver's commitment regardless of filename")
+	}
+}
+
+func TestVerifyErrorsOnUnknownGiver(t *testing.T) {
+	dir, _, _ := sealTestGroup(t)
+
+	unknown := filepath.Join(dir, "dave.txt")
+	contents := "giver: dave\nreceiver: alice\nsalt: " + strings.Repeat("00", 32) + "\n"
+	if err := os.WriteFile(unknown, []byte(contents), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := Verify(filepath.Join(dir, "commitments.json"), unknown); err == nil {
+		t.Fatal("Verify: want an error when the envelope's giver has no commitment")
+	}
+}
@@ -0,0 +1,60 @@
+// Package locale loads unsecret-santa's embedded translation bundles and
+// exposes a single T helper for looking up a message by ID, so every
+// user-facing string can be selected by --lang flag or LANG environment
+// variable instead of being hardcoded in English.
+package locale
+
+import (
+	"embed"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.toml
+var bundleFiles embed.FS
+
+var bundle *i18n.Bundle
+var localizer *i18n.Localizer
+
+func init() {
+	bundle = i18n.NewBundle(language.English)
+	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+
+	entries, err := bundleFiles.ReadDir("locales")
+	if err != nil {
+		panic("locale: could not read embedded bundles: " + err.Error())
+	}
+	for _, entry := range entries {
+		data, err := bundleFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic("locale: could not read bundle " + entry.Name() + ": " + err.Error())
+		}
+		bundle.MustParseMessageFileBytes(data, entry.Name())
+	}
+
+	SetLang("")
+}
+
+// SetLang selects the active language. An empty lang falls back to the
+// LANG environment variable, then to English.
+func SetLang(lang string) {
+	localizer = i18n.NewLocalizer(bundle, lang, os.Getenv("LANG"), language.English.String())
+}
+
+// T looks up messageID in the active language and renders it against
+// data. If the message is missing it falls back to the raw messageID
+// rather than failing, since a missing translation shouldn't crash the
+// draw.
+func T(messageID string, data map[string]interface{}) string {
+	msg, err := localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		TemplateData: data,
+	})
+	if err != nil {
+		return messageID
+	}
+	return msg
+}
@@ -0,0 +1,17 @@
+// Package notify delivers each giver their assignment directly, so that
+// once a pairing has been drawn, no human - including whoever ran the
+// draw - needs to see the full giver/receiver mapping.
+package notify
+
+// Notifier tells a single giver who they are buying a gift for.
+type Notifier interface {
+	Notify(giver, receiver string) error
+}
+
+// TokenNotifier hands a giver a private link to view their own
+// assignment. It never receives the receiver itself, so a caller that
+// only has a TokenNotifier (such as the server subsystem issuing view
+// tokens after a draw) cannot reconstruct anyone's pairing.
+type TokenNotifier interface {
+	NotifyToken(giver, url string) error
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"net/smtp"
+	"text/template"
+)
+
+const defaultTemplate = "Hi {{.Giver}},\n\nYou are the Secret Santa for {{.Receiver}}!\n"
+const defaultTokenTemplate = "Hi {{.Giver}},\n\nView your Secret Santa assignment privately here:\n{{.URL}}\n"
+
+// SMTPConfig describes how to reach the outgoing mail server and how to
+// map participants to their email addresses.
+type SMTPConfig struct {
+	Host          string            `json:"host"`
+	Port          string            `json:"port"`
+	Username      string            `json:"username"`
+	Password      string            `json:"password"`
+	From          string            `json:"from"`
+	Addresses     map[string]string `json:"addresses"`     // participant name -> email
+	Template      string            `json:"template"`      // text/template source; defaults to defaultTemplate
+	TokenTemplate string            `json:"tokenTemplate"` // text/template source for NotifyToken; defaults to defaultTokenTemplate
+}
+
+// SMTPNotifier sends each giver their assignment in its own email, using
+// net/smtp with PLAIN auth.
+type SMTPNotifier struct {
+	cfg       SMTPConfig
+	tmpl      *template.Template
+	tokenTmpl *template.Template
+}
+
+// NewSMTPNotifier builds an SMTPNotifier from cfg, parsing the message
+// templates once up front so a template with a syntax error is rejected
+// before any email goes out. A template that parses but fails to execute
+// (for example, one that references an unknown field) still makes Notify
+// or NotifyToken return an error.
+func NewSMTPNotifier(cfg SMTPConfig) (*SMTPNotifier, error) {
+	source := cfg.Template
+	if source == "" {
+		source = defaultTemplate
+	}
+
+	tmpl, err := template.New("notify").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse notification template: %w", err)
+	}
+
+	tokenSource := cfg.TokenTemplate
+	if tokenSource == "" {
+		tokenSource = defaultTokenTemplate
+	}
+
+	tokenTmpl, err := template.New("notify-token").Parse(tokenSource)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse token notification template: %w", err)
+	}
+
+	return &SMTPNotifier{cfg: cfg, tmpl: tmpl, tokenTmpl: tokenTmpl}, nil
+}
+
+// Notify emails giver their assignment. The receiver is never logged or
+// returned to the caller - it only ever appears in the outgoing message.
+func (n *SMTPNotifier) Notify(giver, receiver string) error {
+	var body bytes.Buffer
+	if err := n.tmpl.Execute(&body, struct{ Giver, Receiver string }{giver, receiver}); err != nil {
+		return fmt.Errorf("could not render notification for %s: %w", giver, err)
+	}
+	return n.send(giver, "Your Secret Santa assignment", body.String())
+}
+
+// NotifyToken emails giver a private link to view their own assignment.
+// Unlike Notify, it never sees the receiver - only the URL the giver
+// should visit - so a caller wired to the TokenNotifier interface alone
+// cannot reconstruct anyone's pairing.
+func (n *SMTPNotifier) NotifyToken(giver, url string) error {
+	var body bytes.Buffer
+	if err := n.tokenTmpl.Execute(&body, struct{ Giver, URL string }{giver, url}); err != nil {
+		return fmt.Errorf("could not render token notification for %s: %w", giver, err)
+	}
+	return n.send(giver, "View your Secret Santa assignment", body.String())
+}
+
+// send emails body to giver's configured address with the given subject,
+// shared by Notify and NotifyToken.
+func (n *SMTPNotifier) send(giver, subject, body string) error {
+	to, ok := n.cfg.Addresses[giver]
+	if !ok {
+		return fmt.Errorf("no email address configured for %s", giver)
+	}
+
+	msg := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s", to, subject, body)
+
+	addr := fmt.Sprintf("%s:%s", n.cfg.Host, n.cfg.Port)
+	auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+	if err := smtp.SendMail(addr, auth, n.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("could not email %s: %w", giver, err)
+	}
+
+	return nil
+}
+
+// Addresses exposes the configured giver -> email map so callers can
+// print recipients (e.g. for --dry-run) without ever touching the
+// pairing itself.
+func (n *SMTPNotifier) Addresses() map[string]string {
+	return n.cfg.Addresses
+}
@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// smtpStub is a minimal SMTP server, just capable enough to exercise
+// net/smtp.SendMail end to end: it advertises AUTH PLAIN (accepting any
+// credentials), and records every message it receives so tests can
+// assert on the subject/body SMTPNotifier actually sent. Optionally
+// rejects RCPT TO for a specific address, to exercise the send failure
+// path.
+type smtpStub struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	received []receivedMessage
+	rejectTo string
+}
+
+type receivedMessage struct {
+	from string
+	to   string
+	data string
+}
+
+func startSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	s := &smtpStub{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+
+	go s.serve()
+	return s
+}
+
+func (s *smtpStub) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *smtpStub) messages() []receivedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMessage(nil), s.received...)
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 smtp.test ESMTP\r\n")
+
+	var from, to string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"):
+			fmt.Fprint(conn, "250-smtp.test greets you\r\n250 AUTH PLAIN LOGIN\r\n")
+		case strings.HasPrefix(upper, "AUTH"):
+			fmt.Fprint(conn, "235 2.7.0 Authentication successful\r\n")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			from = line[len("MAIL FROM:"):]
+			fmt.Fprint(conn, "250 OK\r\n")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			to = line[len("RCPT TO:"):]
+			s.mu.Lock()
+			reject := s.rejectTo != "" && strings.Contains(to, s.rejectTo)
+			s.mu.Unlock()
+			if reject {
+				fmt.Fprint(conn, "550 no such user\r\n")
+				continue
+			}
+			fmt.Fprint(conn, "250 OK\r\n")
+		case upper == "DATA":
+			fmt.Fprint(conn, "354 Start mail input; end with <CRLF>.<CRLF>\r\n")
+			var body strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(dataLine, "\r\n") == "." {
+					break
+				}
+				body.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.received = append(s.received, receivedMessage{from: from, to: to, data: body.String()})
+			s.mu.Unlock()
+			fmt.Fprint(conn, "250 OK\r\n")
+		case upper == "QUIT":
+			fmt.Fprint(conn, "221 Bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "500 unrecognized command\r\n")
+		}
+	}
+}
+
+func testConfig(t *testing.T, stub *smtpStub) SMTPConfig {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(stub.addr())
+	if err != nil {
+		t.Fatalf("net.SplitHostPort: %v", err)
+	}
+	return SMTPConfig{
+		Host:      host,
+		Port:      port,
+		Username:  "user",
+		Password:  "pass",
+		From:      "santa@example.com",
+		Addresses: map[string]string{"alice": "alice@example.com"},
+	}
+}
+
+func TestNotifySendsEachGiverTheirReceiver(t *testing.T) {
+	stub := startSMTPStub(t)
+
+	n, err := NewSMTPNotifier(testConfig(t, stub))
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier: %v", err)
+	}
+
+	if err := n.Notify("alice", "bob"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	msgs := stub.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	if !strings.Contains(msgs[0].data, "bob") {
+		t.Fatalf("message body %q does not mention the receiver", msgs[0].data)
+	}
+}
+
+func TestNotifyTokenNeverMentionsReceiver(t *testing.T) {
+	stub := startSMTPStub(t)
+
+	n, err := NewSMTPNotifier(testConfig(t, stub))
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier: %v", err)
+	}
+
+	if err := n.NotifyToken("alice", "https://santa.example.com/groups/1/pairings/tok"); err != nil {
+		t.Fatalf("NotifyToken: %v", err)
+	}
+
+	msgs := stub.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	if !strings.Contains(msgs[0].data, "https://santa.example.com/groups/1/pairings/tok") {
+		t.Fatalf("message body %q does not contain the token URL", msgs[0].data)
+	}
+	if strings.Contains(msgs[0].data, "bob") {
+		t.Fatalf("message body %q leaked a receiver it was never given", msgs[0].data)
+	}
+}
+
+func TestNotifyUnknownGiverErrors(t *testing.T) {
+	stub := startSMTPStub(t)
+
+	n, err := NewSMTPNotifier(testConfig(t, stub))
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier: %v", err)
+	}
+
+	if err := n.Notify("dave", "bob"); err == nil {
+		t.Fatal("Notify: want an error for a giver with no configured address, got nil")
+	}
+}
+
+func TestNotifyPropagatesSMTPFailure(t *testing.T) {
+	stub := startSMTPStub(t)
+	stub.rejectTo = "alice@example.com"
+
+	n, err := NewSMTPNotifier(testConfig(t, stub))
+	if err != nil {
+		t.Fatalf("NewSMTPNotifier: %v", err)
+	}
+
+	if err := n.Notify("alice", "bob"); err == nil {
+		t.Fatal("Notify: want an error when the server rejects the recipient, got nil")
+	}
+}
@@ -0,0 +1,355 @@
+// Package pairing contains the Secret Santa pairing algorithm shared by
+// the CLI and the HTTP server, so both can draw a pairing without either
+// one owning the solving logic.
+package pairing
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+)
+
+// exactEnumerationThreshold is the largest group size for which we still
+// enumerate every valid pairing before choosing one uniformly at random.
+// Above it, enumeration is factorial in the group size and routinely
+// exhausts memory with dense exclusions, so Draw switches to rejection
+// sampling instead.
+const exactEnumerationThreshold = 12
+
+// maxRejectionAttemptsPerParticipantSquared bounds how many rejection
+// sampling attempts Draw makes (as attempts <= k*n^2) before it falls
+// back to randomized backtracking.
+const maxRejectionAttemptsPerParticipantSquared = 10
+
+// Exclusion defines a rule where a Giver cannot give a gift to a Receiver.
+type Exclusion struct {
+	Giver    string `json:"giver" yaml:"giver"`
+	Receiver string `json:"receiver" yaml:"receiver"`
+}
+
+// Pairing holds all {giver: receiver} pairings for a single valid solution.
+type Pairing map[string]string
+
+// RuleSet is the full set of exclusion rules a config can express. Plain
+// Exclusions remain the lowest-level primitive; Families, Bidirectional
+// and Previous are all lowered into that same shape by
+// createExclusionMap. NoReciprocity depends on the pairing being built,
+// so it is not in that map and is checked during the draw instead.
+type RuleSet struct {
+	// Exclusions are explicit giver/receiver bans.
+	Exclusions []Exclusion `json:"exclusions" yaml:"exclusions"`
+
+	// Families groups participants who may not give to anyone else in
+	// their own group, e.g. [[alice, bob], [carol, dan]].
+	Families [][]string `json:"families" yaml:"families"`
+
+	// Bidirectional mirrors every Exclusion and Previous entry, so
+	// {giver: A, receiver: B} also forbids B giving to A.
+	Bidirectional bool `json:"bidirectional" yaml:"bidirectional"`
+
+	// NoReciprocity forbids a drawn pairing where A gives to B and B
+	// also gives to A, regardless of whether that pair was explicitly
+	// excluded.
+	NoReciprocity bool `json:"no_reciprocity" yaml:"no_reciprocity"`
+
+	// Previous lists last year's pairings, which are automatically
+	// excluded this year.
+	Previous []Exclusion `json:"previous" yaml:"previous"`
+}
+
+// Draw solves the pairing problem for the given participants and plain
+// exclusions and returns one valid Pairing, along with the count that
+// DrawWithRules reports. For groups of up to exactEnumerationThreshold
+// participants the Pairing is chosen uniformly at random. Larger groups
+// are sampled as described on DrawWithRules, and that choice is not
+// exactly uniform.
+//
+// Draw itself never prints or otherwise reveals the pairing; that is
+// left to the caller.
+func Draw(participants []string, exclusions []Exclusion) (Pairing, int, error) {
+	return DrawWithRules(participants, RuleSet{Exclusions: exclusions})
+}
+
+// DrawWithRules is like Draw but accepts the full RuleSet, so callers can
+// express family groups, bidirectional bans, no-reciprocity and
+// previous-year history instead of only flat Exclusions.
+//
+// For small groups it still enumerates every valid pairing and reports
+// how many exist. Beyond exactEnumerationThreshold participants it
+// switches to rejection sampling (falling back to randomized
+// backtracking if sampling can't find a legal pairing quickly), in which
+// case the returned count is -1 because the total is never computed.
+func DrawWithRules(participants []string, rules RuleSet) (Pairing, int, error) {
+	exclusionMap := createExclusionMap(participants, rules)
+	rng := newRand()
+
+	if len(participants) <= exactEnumerationThreshold {
+		return drawExact(participants, exclusionMap, rules.NoReciprocity, rng)
+	}
+
+	n := len(participants)
+	maxAttempts := maxRejectionAttemptsPerParticipantSquared * n * n
+	if sampled, ok := rejectionSample(participants, exclusionMap, rules.NoReciprocity, rng, maxAttempts); ok {
+		return sampled, -1, nil
+	}
+
+	found, err := backtrackRandom(participants, exclusionMap, rules.NoReciprocity, rng)
+	if err != nil {
+		return nil, 0, err
+	}
+	return found, -1, nil
+}
+
+// Count solves the pairing problem exactly and returns the number of
+// valid pairings, without drawing one. It is only practical for small
+// groups: above exactEnumerationThreshold participants it hits the same
+// factorial blowup that pushes DrawWithRules to rejection sampling, so
+// Count refuses instead of enumerating forever.
+func Count(participants []string, rules RuleSet) (int, error) {
+	if len(participants) > exactEnumerationThreshold {
+		return 0, fmt.Errorf("count is only supported for %d or fewer participants (got %d); re-run without --count-only to draw instead", exactEnumerationThreshold, len(participants))
+	}
+
+	exclusionMap := createExclusionMap(participants, rules)
+	allValidPairings := make([]Pairing, 0)
+	currentPairing := make(Pairing)
+	availableReceivers := availableSet(participants)
+
+	findPairingsRecursive(participants, 0, currentPairing, availableReceivers, exclusionMap, rules.NoReciprocity, &allValidPairings)
+
+	if len(allValidPairings) == 0 {
+		return 0, fmt.Errorf("no valid pairings could be found with these rules")
+	}
+	return len(allValidPairings), nil
+}
+
+// drawExact enumerates every valid pairing and selects one uniformly at
+// random, reporting the total count found.
+func drawExact(participants []string, exclusionMap map[string]map[string]bool, noReciprocity bool, rng *rand.Rand) (Pairing, int, error) {
+	allValidPairings := make([]Pairing, 0)
+	currentPairing := make(Pairing)
+	availableReceivers := availableSet(participants)
+
+	findPairingsRecursive(participants, 0, currentPairing, availableReceivers, exclusionMap, noReciprocity, &allValidPairings)
+
+	count := len(allValidPairings)
+	if count == 0 {
+		return nil, 0, fmt.Errorf("no valid pairings could be found with these rules")
+	}
+
+	return allValidPairings[rng.IntN(count)], count, nil
+}
+
+// findPairingsRecursive is the core backtracking algorithm used to
+// enumerate every valid pairing for small groups.
+func findPairingsRecursive(
+	allGivers []string,
+	giverIndex int,
+	currentPairing Pairing,
+	availableReceivers map[string]bool,
+	exclusionMap map[string]map[string]bool,
+	noReciprocity bool,
+	allValidPairings *[]Pairing,
+) {
+	if giverIndex == len(allGivers) {
+		solutionCopy := make(Pairing)
+		for k, v := range currentPairing {
+			solutionCopy[k] = v
+		}
+		*allValidPairings = append(*allValidPairings, solutionCopy)
+		return
+	}
+
+	currentGiver := allGivers[giverIndex]
+	forbiddenReceivers := exclusionMap[currentGiver]
+
+	for potentialReceiver, isAvailable := range availableReceivers {
+		if !isAvailable || !isLegal(currentPairing, forbiddenReceivers, noReciprocity, currentGiver, potentialReceiver) {
+			continue
+		}
+
+		currentPairing[currentGiver] = potentialReceiver
+		availableReceivers[potentialReceiver] = false
+
+		findPairingsRecursive(allGivers, giverIndex+1, currentPairing, availableReceivers, exclusionMap, noReciprocity, allValidPairings)
+
+		availableReceivers[potentialReceiver] = true
+		delete(currentPairing, currentGiver)
+	}
+}
+
+// rejectionSample repeatedly walks givers in a random order, at each
+// step choosing uniformly among the receivers that are still available,
+// not excluded, and (if noReciprocity is set) not already paired back to
+// this giver. A dead end - no legal receiver left for some giver -
+// discards the whole attempt and starts over, up to maxAttempts times.
+func rejectionSample(participants []string, exclusionMap map[string]map[string]bool, noReciprocity bool, rng *rand.Rand, maxAttempts int) (Pairing, bool) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		order := shuffled(participants, rng)
+		assigned := make(Pairing, len(participants))
+		available := availableSet(participants)
+
+		dead := false
+		for _, giver := range order {
+			forbidden := exclusionMap[giver]
+
+			legal := make([]string, 0, len(participants))
+			for _, receiver := range participants {
+				if available[receiver] && isLegal(assigned, forbidden, noReciprocity, giver, receiver) {
+					legal = append(legal, receiver)
+				}
+			}
+			if len(legal) == 0 {
+				dead = true
+				break
+			}
+
+			chosen := legal[rng.IntN(len(legal))]
+			assigned[giver] = chosen
+			available[chosen] = false
+		}
+
+		if !dead {
+			return assigned, true
+		}
+	}
+	return nil, false
+}
+
+// backtrackRandom finds a single valid pairing via backtracking with
+// randomized child ordering at every level. Unlike findPairingsRecursive
+// it stops at the first solution, which guarantees termination even on
+// pathological exclusion sets where rejection sampling keeps dead-ending.
+func backtrackRandom(participants []string, exclusionMap map[string]map[string]bool, noReciprocity bool, rng *rand.Rand) (Pairing, error) {
+	currentPairing := make(Pairing)
+	availableReceivers := availableSet(participants)
+
+	if backtrackStep(participants, 0, currentPairing, availableReceivers, exclusionMap, noReciprocity, rng) {
+		return currentPairing, nil
+	}
+	return nil, fmt.Errorf("no valid pairings could be found with these rules")
+}
+
+func backtrackStep(
+	allGivers []string,
+	giverIndex int,
+	currentPairing Pairing,
+	availableReceivers map[string]bool,
+	exclusionMap map[string]map[string]bool,
+	noReciprocity bool,
+	rng *rand.Rand,
+) bool {
+	if giverIndex == len(allGivers) {
+		return true
+	}
+
+	currentGiver := allGivers[giverIndex]
+	forbiddenReceivers := exclusionMap[currentGiver]
+
+	for _, potentialReceiver := range shuffled(allGivers, rng) {
+		if !availableReceivers[potentialReceiver] || !isLegal(currentPairing, forbiddenReceivers, noReciprocity, currentGiver, potentialReceiver) {
+			continue
+		}
+
+		currentPairing[currentGiver] = potentialReceiver
+		availableReceivers[potentialReceiver] = false
+
+		if backtrackStep(allGivers, giverIndex+1, currentPairing, availableReceivers, exclusionMap, noReciprocity, rng) {
+			return true
+		}
+
+		availableReceivers[potentialReceiver] = true
+		delete(currentPairing, currentGiver)
+	}
+	return false
+}
+
+// isLegal reports whether giver may be paired with potentialReceiver:
+// not themselves, not excluded, and - when noReciprocity is set - not
+// already paired back to giver.
+func isLegal(currentPairing Pairing, forbiddenReceivers map[string]bool, noReciprocity bool, giver, potentialReceiver string) bool {
+	if giver == potentialReceiver {
+		return false
+	}
+	if forbiddenReceivers[potentialReceiver] {
+		return false
+	}
+	if noReciprocity && currentPairing[potentialReceiver] == giver {
+		return false
+	}
+	return true
+}
+
+// availableSet returns a map[string]bool with every participant marked
+// available.
+func availableSet(participants []string) map[string]bool {
+	available := make(map[string]bool, len(participants))
+	for _, p := range participants {
+		available[p] = true
+	}
+	return available
+}
+
+// shuffled returns a Fisher-Yates shuffled copy of xs.
+func shuffled(xs []string, rng *rand.Rand) []string {
+	out := make([]string, len(xs))
+	copy(out, xs)
+	rng.Shuffle(len(out), func(i, j int) {
+		out[i], out[j] = out[j], out[i]
+	})
+	return out
+}
+
+// newRand returns a math/rand/v2 source seeded from crypto/rand, so the
+// selected pairing is unpredictable to participants.
+func newRand() *rand.Rand {
+	var seed [16]byte
+	if _, err := cryptorand.Read(seed[:]); err != nil {
+		panic(fmt.Sprintf("pairing: could not seed random source: %v", err))
+	}
+	return rand.New(rand.NewPCG(
+		binary.LittleEndian.Uint64(seed[:8]),
+		binary.LittleEndian.Uint64(seed[8:]),
+	))
+}
+
+// createExclusionMap compiles a RuleSet down to the flat giver -> set of
+// forbidden receivers shape the solver uses for O(1) lookups. Explicit
+// Exclusions, Previous-year pairings, and Families are all lowered into
+// the same map; Bidirectional mirrors each entry it adds.
+func createExclusionMap(participants []string, rules RuleSet) map[string]map[string]bool {
+	exMap := make(map[string]map[string]bool)
+	for _, p := range participants {
+		exMap[p] = make(map[string]bool)
+	}
+
+	ban := func(giver, receiver string) {
+		if _, ok := exMap[giver]; ok {
+			exMap[giver][receiver] = true
+		}
+		if rules.Bidirectional {
+			if _, ok := exMap[receiver]; ok {
+				exMap[receiver][giver] = true
+			}
+		}
+	}
+
+	for _, ex := range rules.Exclusions {
+		ban(ex.Giver, ex.Receiver)
+	}
+	for _, ex := range rules.Previous {
+		ban(ex.Giver, ex.Receiver)
+	}
+	for _, family := range rules.Families {
+		for _, giver := range family {
+			for _, receiver := range family {
+				if giver != receiver {
+					ban(giver, receiver)
+				}
+			}
+		}
+	}
+
+	return exMap
+}
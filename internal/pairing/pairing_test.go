@@ -0,0 +1,156 @@
+package pairing
+
+import (
+	"fmt"
+	"testing"
+)
+
+// assertValidPairing checks the universal invariants every Draw/DrawWithRules
+// result must satisfy regardless of which solving strategy produced it: it
+// is a total derangement over participants and respects the given RuleSet.
+func assertValidPairing(t *testing.T, participants []string, rules RuleSet, p Pairing) {
+	t.Helper()
+
+	if len(p) != len(participants) {
+		t.Fatalf("pairing has %d givers, want %d", len(p), len(participants))
+	}
+
+	exclusionMap := createExclusionMap(participants, rules)
+	receivedBy := make(map[string]string, len(p))
+
+	for _, giver := range participants {
+		receiver, ok := p[giver]
+		if !ok {
+			t.Fatalf("giver %s missing from pairing", giver)
+		}
+		if receiver == giver {
+			t.Fatalf("giver %s assigned to themselves", giver)
+		}
+		if exclusionMap[giver][receiver] {
+			t.Fatalf("giver %s assigned to excluded receiver %s", giver, receiver)
+		}
+		if prior, ok := receivedBy[receiver]; ok {
+			t.Fatalf("receiver %s assigned to both %s and %s", receiver, prior, giver)
+		}
+		receivedBy[receiver] = giver
+	}
+
+	if rules.NoReciprocity {
+		for giver, receiver := range p {
+			if p[receiver] == giver {
+				t.Fatalf("reciprocal pair found: %s <-> %s", giver, receiver)
+			}
+		}
+	}
+}
+
+func TestDrawExactProducesValidPairings(t *testing.T) {
+	participants := []string{"alice", "bob", "carol", "dan"}
+	exclusions := []Exclusion{{Giver: "alice", Receiver: "bob"}}
+
+	for i := 0; i < 50; i++ {
+		p, count, err := Draw(participants, exclusions)
+		if err != nil {
+			t.Fatalf("Draw: %v", err)
+		}
+		if count <= 0 {
+			t.Fatalf("Draw: want a positive count for a small group, got %d", count)
+		}
+		assertValidPairing(t, participants, RuleSet{Exclusions: exclusions}, p)
+	}
+}
+
+func TestDrawWithRulesRejectionSamplingRespectsFamilies(t *testing.T) {
+	participants := make([]string, 0, exactEnumerationThreshold+4)
+	for i := 0; i < exactEnumerationThreshold+4; i++ {
+		participants = append(participants, fmt.Sprintf("p%d", i))
+	}
+
+	rules := RuleSet{
+		Families: [][]string{
+			{participants[0], participants[1], participants[2]},
+			{participants[3], participants[4]},
+		},
+		NoReciprocity: true,
+	}
+
+	for i := 0; i < 20; i++ {
+		p, count, err := DrawWithRules(participants, rules)
+		if err != nil {
+			t.Fatalf("DrawWithRules: %v", err)
+		}
+		if count != -1 {
+			t.Fatalf("DrawWithRules: want count -1 for a large group (sampled, not enumerated), got %d", count)
+		}
+		assertValidPairing(t, participants, rules, p)
+	}
+}
+
+func TestDrawWithRulesFallsBackWhenRejectionSamplingDeadEnds(t *testing.T) {
+	// A dense ring of exclusions (everyone may only give to the next two
+	// participants) leaves rejection sampling dead-ending constantly on a
+	// large group, forcing the randomized-backtracking fallback. The group
+	// has to be this large: at 15 participants roughly 1 attempt in 70
+	// still succeeds, well within the attempt cap, whereas at 48 practically
+	// every attempt dead-ends.
+	n := 4 * exactEnumerationThreshold
+	participants := make([]string, n)
+	for i := range participants {
+		participants[i] = fmt.Sprintf("p%d", i)
+	}
+
+	var exclusions []Exclusion
+	for i, giver := range participants {
+		for j, receiver := range participants {
+			if j == (i+1)%n || j == (i+2)%n {
+				continue
+			}
+			exclusions = append(exclusions, Exclusion{Giver: giver, Receiver: receiver})
+		}
+	}
+	rules := RuleSet{Exclusions: exclusions}
+
+	p, count, err := DrawWithRules(participants, rules)
+	if err != nil {
+		t.Fatalf("DrawWithRules: %v", err)
+	}
+	if count != -1 {
+		t.Fatalf("DrawWithRules: want count -1, got %d", count)
+	}
+	assertValidPairing(t, participants, rules, p)
+}
+
+func TestDrawWithRulesNoValidPairings(t *testing.T) {
+	participants := []string{"alice", "bob"}
+	rules := RuleSet{Exclusions: []Exclusion{
+		{Giver: "alice", Receiver: "bob"},
+		{Giver: "bob", Receiver: "alice"},
+	}}
+
+	if _, _, err := DrawWithRules(participants, rules); err == nil {
+		t.Fatal("DrawWithRules: want an error when no pairing is possible, got nil")
+	}
+}
+
+func TestCountMatchesEnumeratedPairings(t *testing.T) {
+	participants := []string{"alice", "bob", "carol"}
+	count, err := Count(participants, RuleSet{})
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	// The only derangements of 3 elements are the two 3-cycles.
+	if count != 2 {
+		t.Fatalf("Count: got %d, want 2", count)
+	}
+}
+
+func TestCountRefusesGroupsAboveExactEnumerationThreshold(t *testing.T) {
+	participants := make([]string, exactEnumerationThreshold+1)
+	for i := range participants {
+		participants[i] = fmt.Sprintf("p%d", i)
+	}
+
+	if _, err := Count(participants, RuleSet{}); err == nil {
+		t.Fatal("Count: want an error above exactEnumerationThreshold, got nil")
+	}
+}
@@ -0,0 +1,212 @@
+// Package server exposes unsecret-santa's group drawing as an HTTP API,
+// so an organizer can manage several groups without ever seeing the
+// pairing themselves: each participant is handed a one-time token URL
+// that reveals only their own assignment.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/d3spis3d/unsecret-santa/internal/notify"
+	"github.com/d3spis3d/unsecret-santa/internal/pairing"
+	"github.com/d3spis3d/unsecret-santa/internal/store"
+)
+
+// Server holds the dependencies shared by the HTTP handlers.
+type Server struct {
+	store   store.Store
+	baseURL string // scheme+host prepended to links emailed to participants
+
+	// newNotifier builds the TokenNotifier used to deliver a group's
+	// tokens, from that group's own SMTPConfig. It's a factory rather
+	// than a single injected Notifier because each group carries its own
+	// notification config; tests can swap it out for a fake so drawGroup
+	// can be exercised without a live SMTP server.
+	newNotifier func(notify.SMTPConfig) (notify.TokenNotifier, error)
+
+	// groupLocks holds one *sync.Mutex per group ID, serializing the
+	// whole check-then-act sequence in drawGroup. SaveDraw's
+	// compare-and-swap already guarantees only one pairing is ever saved
+	// for a group, but without this, concurrent requests that all lose
+	// the draw race would still all proceed to deliver the winner's
+	// tokens, double-emailing every participant.
+	groupLocks sync.Map
+}
+
+// New returns a Server backed by the given Store. baseURL is prepended to
+// the one-time pairing links emailed to participants (e.g.
+// "https://santa.example.com"); pass "" to emit path-only links.
+func New(s store.Store, baseURL string) *Server {
+	return &Server{
+		store:   s,
+		baseURL: baseURL,
+		newNotifier: func(cfg notify.SMTPConfig) (notify.TokenNotifier, error) {
+			return notify.NewSMTPNotifier(cfg)
+		},
+	}
+}
+
+// Router builds the gin.Engine with all routes registered.
+func (s *Server) Router() *gin.Engine {
+	r := gin.Default()
+
+	r.POST("/groups", s.createGroup)
+	r.POST("/groups/:id/draw", s.drawGroup)
+	r.GET("/groups/:id/pairings/:token", s.getPairing)
+
+	return r
+}
+
+type createGroupRequest struct {
+	Participants []string            `json:"participants" binding:"required"`
+	Exclusions   []pairing.Exclusion `json:"exclusions"`
+
+	// Notifications is required before the group can be drawn: tokens are
+	// emailed to each participant individually rather than ever being
+	// returned to whoever calls /draw.
+	Notifications *notify.SMTPConfig `json:"notifications"`
+}
+
+func (s *Server) createGroup(c *gin.Context) {
+	var req createGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	g, err := s.store.CreateGroup(store.Group{
+		Participants:  req.Participants,
+		Exclusions:    req.Exclusions,
+		Notifications: req.Notifications,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": g.ID})
+}
+
+// lockFor returns the mutex guarding group id's draw-and-deliver
+// sequence, creating one on first use.
+func (s *Server) lockFor(id string) *sync.Mutex {
+	mu, _ := s.groupLocks.LoadOrStore(id, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+func (s *Server) drawGroup(c *gin.Context) {
+	id := c.Param("id")
+
+	mu := s.lockFor(id)
+	mu.Lock()
+	defer mu.Unlock()
+
+	g, err := s.store.GetGroup(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		return
+	}
+
+	if g.Delivered {
+		c.JSON(http.StatusConflict, gin.H{"error": "group has already been drawn"})
+		return
+	}
+
+	// Notifications must be configured up front: tokens are delivered to
+	// each participant individually by email, never returned to whoever
+	// triggered the draw, or the caller could reconstruct the whole
+	// pairing by walking every token through getPairing.
+	if g.Notifications == nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "group has no notifications configured; cannot draw without a way to deliver tokens individually"})
+		return
+	}
+	notifier, err := s.newNotifier(*g.Notifications)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A prior call may have already drawn and saved a pairing but failed
+	// to notify everyone (e.g. a transient SMTP error). Retry delivery of
+	// that same pairing instead of drawing a new one each time, or a
+	// participant who was already notified could receive a link to a
+	// pairing that's since been overwritten.
+	result, tokens := g.Pairing, g.Tokens
+	if result == nil {
+		result, _, err = pairing.Draw(g.Participants, g.Exclusions)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		tokens = make(map[string]string, len(result))
+		for giver := range result {
+			token, err := store.NewToken()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			tokens[giver] = token
+		}
+
+		if err := s.store.SaveDraw(id, result, tokens); err != nil {
+			if !errors.Is(err, store.ErrAlreadyDrawn) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			// Lost the race to draw this group: another request's
+			// SaveDraw won, so discard the pairing drawn above and
+			// deliver the winner's instead.
+			winner, err := s.store.GetGroup(id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if winner.Delivered {
+				c.JSON(http.StatusConflict, gin.H{"error": "group has already been drawn"})
+				return
+			}
+			result, tokens = winner.Pairing, winner.Tokens
+		}
+	}
+
+	var failed []string
+	for giver, token := range tokens {
+		url := fmt.Sprintf("%s/groups/%s/pairings/%s", s.baseURL, id, token)
+		if err := notifier.NotifyToken(giver, url); err != nil {
+			failed = append(failed, giver)
+		}
+	}
+	if len(failed) > 0 {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to notify: %v", failed)})
+		return
+	}
+
+	if err := s.store.MarkDelivered(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The caller only learns that the draw happened - never the tokens,
+	// which went out individually to each participant.
+	c.JSON(http.StatusOK, gin.H{"status": "drawn", "notified": len(tokens)})
+}
+
+func (s *Server) getPairing(c *gin.Context) {
+	id := c.Param("id")
+	token := c.Param("token")
+
+	receiver, err := s.store.GetReceiverByToken(id, token)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pairing for that token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"receiver": receiver})
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/d3spis3d/unsecret-santa/internal/notify"
+	"github.com/d3spis3d/unsecret-santa/internal/store"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeNotifier is a notify.TokenNotifier that records every call instead
+// of sending real email, so drawGroup can be tested without a live SMTP
+// server. An optional failFor set makes NotifyToken fail for specific
+// givers, to exercise the partial-delivery-failure path.
+type fakeNotifier struct {
+	mu      sync.Mutex
+	sent    map[string]string // giver -> url
+	failFor map[string]bool
+}
+
+func (f *fakeNotifier) NotifyToken(giver, url string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failFor[giver] {
+		return errFakeDeliveryFailed
+	}
+	if f.sent == nil {
+		f.sent = make(map[string]string)
+	}
+	f.sent[giver] = url
+	return nil
+}
+
+var errFakeDeliveryFailed = &fakeDeliveryError{}
+
+type fakeDeliveryError struct{}
+
+func (*fakeDeliveryError) Error() string { return "fake: delivery failed" }
+
+func newTestServer(t *testing.T, notifier *fakeNotifier) (*Server, store.Store) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	srv := New(s, "https://santa.example.com")
+	srv.newNotifier = func(notify.SMTPConfig) (notify.TokenNotifier, error) {
+		return notifier, nil
+	}
+	return srv, s
+}
+
+func TestDrawGroupNeverReturnsTokensToTheCaller(t *testing.T) {
+	srv, s := newTestServer(t, &fakeNotifier{})
+
+	g, err := s.CreateGroup(store.Group{
+		Participants:  []string{"alice", "bob"},
+		Notifications: &notify.SMTPConfig{Addresses: map[string]string{"alice": "a@x.com", "bob": "b@x.com"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/groups/"+g.ID+"/draw", nil)
+	srv.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /draw: got status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal response: %v", err)
+	}
+	if _, ok := resp["tokens"]; ok {
+		t.Fatalf("response %v must never contain tokens", resp)
+	}
+	if _, ok := resp["pairing"]; ok {
+		t.Fatalf("response %v must never contain the pairing", resp)
+	}
+
+	saved, err := s.GetGroup(g.ID)
+	if err != nil {
+		t.Fatalf("GetGroup: %v", err)
+	}
+	if !saved.Delivered {
+		t.Fatal("GetGroup: want group marked Delivered after a successful draw")
+	}
+}
+
+func TestDrawGroupRejectsRedraw(t *testing.T) {
+	srv, s := newTestServer(t, &fakeNotifier{})
+
+	g, err := s.CreateGroup(store.Group{
+		Participants:  []string{"alice", "bob"},
+		Notifications: &notify.SMTPConfig{Addresses: map[string]string{"alice": "a@x.com", "bob": "b@x.com"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	req := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/groups/"+g.ID+"/draw", nil))
+		return rec
+	}
+
+	if rec := req(); rec.Code != http.StatusOK {
+		t.Fatalf("first draw: got status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if rec := req(); rec.Code != http.StatusConflict {
+		t.Fatalf("second draw: got status %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestDrawGroupConcurrentRequestsNotifyOnlyOnce(t *testing.T) {
+	notifier := &fakeNotifier{}
+	srv, s := newTestServer(t, notifier)
+
+	g, err := s.CreateGroup(store.Group{
+		Participants:  []string{"alice", "bob", "carol", "dave"},
+		Notifications: &notify.SMTPConfig{Addresses: map[string]string{"alice": "a@x.com", "bob": "b@x.com", "carol": "c@x.com", "dave": "d@x.com"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	const racers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	statusCounts := make(map[int]int)
+
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/groups/"+g.ID+"/draw", nil))
+			mu.Lock()
+			defer mu.Unlock()
+			statusCounts[rec.Code]++
+		}()
+	}
+	wg.Wait()
+
+	if statusCounts[http.StatusOK] != 1 {
+		t.Fatalf("got %d 200s across %d concurrent draws, want exactly 1 (statusCounts=%v)", statusCounts[http.StatusOK], racers, statusCounts)
+	}
+	if statusCounts[http.StatusConflict] != racers-1 {
+		t.Fatalf("got %d 409s, want %d (statusCounts=%v)", statusCounts[http.StatusConflict], racers-1, statusCounts)
+	}
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.sent) != 4 {
+		t.Fatalf("NotifyToken was called for %d givers, want exactly 4 (one delivery per participant, no duplicates)", len(notifier.sent))
+	}
+}
+
+func TestDrawGroupRetriesDeliveryWithoutRedrawing(t *testing.T) {
+	failing := &fakeNotifier{failFor: map[string]bool{"alice": true}}
+	srv, s := newTestServer(t, failing)
+
+	g, err := s.CreateGroup(store.Group{
+		Participants:  []string{"alice", "bob"},
+		Notifications: &notify.SMTPConfig{Addresses: map[string]string{"alice": "a@x.com", "bob": "b@x.com"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	draw := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/groups/"+g.ID+"/draw", nil))
+		return rec
+	}
+
+	if rec := draw(); rec.Code != http.StatusBadGateway {
+		t.Fatalf("first draw (alice fails): got status %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+
+	afterFirstAttempt, err := s.GetGroup(g.ID)
+	if err != nil {
+		t.Fatalf("GetGroup: %v", err)
+	}
+	if afterFirstAttempt.Delivered {
+		t.Fatal("GetGroup: group must not be marked Delivered after a failed delivery")
+	}
+
+	failing.mu.Lock()
+	failing.failFor = nil
+	failing.mu.Unlock()
+
+	if rec := draw(); rec.Code != http.StatusOK {
+		t.Fatalf("retry: got status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	final, err := s.GetGroup(g.ID)
+	if err != nil {
+		t.Fatalf("GetGroup: %v", err)
+	}
+	if final.Pairing["alice"] != afterFirstAttempt.Pairing["alice"] {
+		t.Fatalf("retry drew a new pairing instead of reusing the saved one: got %q, want %q", final.Pairing["alice"], afterFirstAttempt.Pairing["alice"])
+	}
+}
+
+func TestGetPairingReturnsOnlyTheCallersReceiver(t *testing.T) {
+	srv, s := newTestServer(t, &fakeNotifier{})
+
+	g, err := s.CreateGroup(store.Group{
+		Participants:  []string{"alice", "bob"},
+		Notifications: &notify.SMTPConfig{Addresses: map[string]string{"alice": "a@x.com", "bob": "b@x.com"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/groups/"+g.ID+"/draw", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("draw: got status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	saved, err := s.GetGroup(g.ID)
+	if err != nil {
+		t.Fatalf("GetGroup: %v", err)
+	}
+
+	getRec := httptest.NewRecorder()
+	srv.Router().ServeHTTP(getRec, httptest.NewRequest(http.MethodGet, "/groups/"+g.ID+"/pairings/"+saved.Tokens["alice"], nil))
+	if getRec.Code != http.StatusOK {
+		t.Fatalf("GET pairing: got status %d, body %s", getRec.Code, getRec.Body.String())
+	}
+
+	var resp map[string]string
+	if err := json.Unmarshal(getRec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal response: %v", err)
+	}
+	if resp["receiver"] != saved.Pairing["alice"] {
+		t.Fatalf("GET pairing: got receiver %q, want %q", resp["receiver"], saved.Pairing["alice"])
+	}
+}
+
+func TestCreateGroupRejectsInvalidJSON(t *testing.T) {
+	srv := New(store.NewMemoryStore(), "")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/groups", bytes.NewBufferString("not json"))
+	req.Header.Set("Content-Type", "application/json")
+	srv.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST /groups with invalid JSON: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
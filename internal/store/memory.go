@@ -0,0 +1,99 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// memoryStore is an in-process Store backed by a map. It is the default
+// backend and is primarily useful for tests and single-instance demos,
+// since its contents do not survive a restart.
+type memoryStore struct {
+	mu     sync.Mutex
+	groups map[string]Group
+	nextID int
+}
+
+// NewMemoryStore returns a Store that keeps all state in memory.
+func NewMemoryStore() Store {
+	return &memoryStore{groups: make(map[string]Group)}
+}
+
+func (s *memoryStore) CreateGroup(g Group) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	g.ID = fmt.Sprintf("%d", s.nextID)
+	s.groups[g.ID] = g
+	return g, nil
+}
+
+func (s *memoryStore) GetGroup(id string) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return Group{}, ErrNotFound
+	}
+	return g, nil
+}
+
+func (s *memoryStore) SaveDraw(id string, pairing map[string]string, tokens map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if g.Pairing != nil {
+		return ErrAlreadyDrawn
+	}
+	g.Pairing = pairing
+	g.Tokens = tokens
+	s.groups[id] = g
+	return nil
+}
+
+func (s *memoryStore) MarkDelivered(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok {
+		return ErrNotFound
+	}
+	g.Delivered = true
+	s.groups[id] = g
+	return nil
+}
+
+func (s *memoryStore) GetReceiverByToken(groupID, token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[groupID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	for giver, t := range g.Tokens {
+		if t == token {
+			return g.Pairing[giver], nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// NewToken generates a URL-safe one-time token for a participant to view
+// their own assignment.
+func NewToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteStore is a Store backed by a SQLite database file, for deployments
+// that need groups and pairings to survive a restart.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) a SQLite database at path
+// and ensures its schema exists.
+func NewSQLiteStore(path string) (Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open sqlite database %s: %w", path, err)
+	}
+
+	const schema = `
+	CREATE TABLE IF NOT EXISTS groups (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		participants  TEXT NOT NULL,
+		exclusions    TEXT NOT NULL,
+		notifications TEXT,
+		pairing       TEXT,
+		tokens        TEXT,
+		delivered     INTEGER NOT NULL DEFAULT 0
+	);`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("could not initialize sqlite schema: %w", err)
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) CreateGroup(g Group) (Group, error) {
+	participants, err := json.Marshal(g.Participants)
+	if err != nil {
+		return Group{}, err
+	}
+	exclusions, err := json.Marshal(g.Exclusions)
+	if err != nil {
+		return Group{}, err
+	}
+	notifications, err := json.Marshal(g.Notifications)
+	if err != nil {
+		return Group{}, err
+	}
+
+	res, err := s.db.Exec(
+		`INSERT INTO groups (participants, exclusions, notifications) VALUES (?, ?, ?)`,
+		string(participants), string(exclusions), string(notifications),
+	)
+	if err != nil {
+		return Group{}, fmt.Errorf("could not create group: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Group{}, err
+	}
+	g.ID = fmt.Sprintf("%d", id)
+	return g, nil
+}
+
+func (s *sqliteStore) GetGroup(id string) (Group, error) {
+	row := s.db.QueryRow(
+		`SELECT id, participants, exclusions, notifications, pairing, tokens, delivered FROM groups WHERE id = ?`, id,
+	)
+
+	var (
+		gid, participants, exclusions  string
+		notifications, pairing, tokens sql.NullString
+		delivered                      bool
+	)
+	if err := row.Scan(&gid, &participants, &exclusions, &notifications, &pairing, &tokens, &delivered); err != nil {
+		if err == sql.ErrNoRows {
+			return Group{}, ErrNotFound
+		}
+		return Group{}, fmt.Errorf("could not load group %s: %w", id, err)
+	}
+
+	g := Group{ID: gid, Delivered: delivered}
+	if err := json.Unmarshal([]byte(participants), &g.Participants); err != nil {
+		return Group{}, err
+	}
+	if err := json.Unmarshal([]byte(exclusions), &g.Exclusions); err != nil {
+		return Group{}, err
+	}
+	if notifications.Valid {
+		if err := json.Unmarshal([]byte(notifications.String), &g.Notifications); err != nil {
+			return Group{}, err
+		}
+	}
+	if pairing.Valid {
+		if err := json.Unmarshal([]byte(pairing.String), &g.Pairing); err != nil {
+			return Group{}, err
+		}
+	}
+	if tokens.Valid {
+		if err := json.Unmarshal([]byte(tokens.String), &g.Tokens); err != nil {
+			return Group{}, err
+		}
+	}
+	return g, nil
+}
+
+func (s *sqliteStore) SaveDraw(id string, pairing map[string]string, tokens map[string]string) error {
+	pairingJSON, err := json.Marshal(pairing)
+	if err != nil {
+		return err
+	}
+	tokensJSON, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	// The "AND pairing IS NULL" guard makes this a compare-and-swap: if two
+	// requests race to draw the same group, only the first UPDATE (the one
+	// that still finds pairing NULL) affects a row. The loser must not
+	// overwrite the winner's pairing, so it gets ErrAlreadyDrawn instead.
+	res, err := s.db.Exec(
+		`UPDATE groups SET pairing = ?, tokens = ? WHERE id = ? AND pairing IS NULL`,
+		string(pairingJSON), string(tokensJSON), id,
+	)
+	if err != nil {
+		return fmt.Errorf("could not save draw for group %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := s.GetGroup(id); err != nil {
+			return err
+		}
+		return ErrAlreadyDrawn
+	}
+	return nil
+}
+
+func (s *sqliteStore) MarkDelivered(id string) error {
+	res, err := s.db.Exec(`UPDATE groups SET delivered = 1 WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("could not mark group %s delivered: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqliteStore) GetReceiverByToken(groupID, token string) (string, error) {
+	g, err := s.GetGroup(groupID)
+	if err != nil {
+		return "", err
+	}
+	for giver, t := range g.Tokens {
+		if t == token {
+			return g.Pairing[giver], nil
+		}
+	}
+	return "", ErrNotFound
+}
@@ -0,0 +1,64 @@
+// Package store defines the pluggable persistence layer used by the
+// server subsystem to keep track of groups and the pairings drawn for
+// them. Callers obtain a Store via NewMemoryStore or NewSQLiteStore and
+// interact with it only through this interface, so additional backends
+// can be added without touching the server package.
+package store
+
+import (
+	"errors"
+
+	"github.com/d3spis3d/unsecret-santa/internal/notify"
+	"github.com/d3spis3d/unsecret-santa/internal/pairing"
+)
+
+// ErrNotFound is returned when a requested Group or token has no match.
+var ErrNotFound = errors.New("store: not found")
+
+// ErrAlreadyDrawn is returned by SaveDraw when the group already has a
+// pairing, so a caller that lost a concurrent race to draw it can fetch
+// and use the winner's pairing instead of overwriting it.
+var ErrAlreadyDrawn = errors.New("store: group already drawn")
+
+// Group is a named set of participants and exclusion rules managed by
+// the server, together with the pairing drawn for it (if any).
+type Group struct {
+	ID            string
+	Participants  []string
+	Exclusions    []pairing.Exclusion
+	Notifications *notify.SMTPConfig // required before a draw can be triggered
+	Pairing       map[string]string  // nil until SaveDraw has recorded a draw
+	Tokens        map[string]string  // participant name -> one-time view token
+	Delivered     bool               // true once every token has been notified
+}
+
+// Store persists groups and their pairings.
+type Store interface {
+	// CreateGroup saves a new group and returns it with an assigned ID.
+	CreateGroup(g Group) (Group, error)
+
+	// GetGroup retrieves a group by ID.
+	GetGroup(id string) (Group, error)
+
+	// SaveDraw atomically records the pairing and per-participant view
+	// tokens for a group that has already been created, but only if the
+	// group doesn't already have a pairing - otherwise it returns
+	// ErrAlreadyDrawn without overwriting what's stored, so two concurrent
+	// draws of the same group can't each save a different pairing and
+	// orphan whichever one loses. It does not mark the group as Delivered
+	// - callers must call MarkDelivered once every participant has
+	// actually been notified, so a draw interrupted by a delivery failure
+	// can be retried against the same pairing instead of either being
+	// stuck forever or silently re-drawing a new one.
+	SaveDraw(id string, pairing map[string]string, tokens map[string]string) error
+
+	// MarkDelivered records that every token for a drawn group has been
+	// successfully handed to its giver, so the group can no longer be
+	// drawn (or re-drawn) again.
+	MarkDelivered(id string) error
+
+	// GetReceiverByToken looks up the receiver assigned to whichever
+	// giver owns the given one-time token, without exposing anyone
+	// else's assignment.
+	GetReceiverByToken(groupID, token string) (string, error)
+}
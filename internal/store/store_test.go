@@ -0,0 +1,108 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testStores returns a fresh memoryStore and a fresh sqliteStore (backed by
+// a temp file) so concurrency behavior is checked against both backends.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+
+	sqliteStore, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestConcurrentSaveDrawOnlyOneWins(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			g, err := s.CreateGroup(Group{Participants: []string{"alice", "bob"}})
+			if err != nil {
+				t.Fatalf("CreateGroup: %v", err)
+			}
+
+			const racers = 20
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			successes := 0
+			alreadyDrawn := 0
+
+			for i := 0; i < racers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					pairing := map[string]string{"alice": "bob", "bob": "alice"}
+					tokens := map[string]string{"alice": string(rune('a' + i)), "bob": string(rune('A' + i))}
+
+					err := s.SaveDraw(g.ID, pairing, tokens)
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						successes++
+					case errors.Is(err, ErrAlreadyDrawn):
+						alreadyDrawn++
+					default:
+						t.Errorf("SaveDraw: unexpected error: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if successes != 1 {
+				t.Fatalf("SaveDraw: got %d successful draws, want exactly 1 (racers=%d)", successes, racers)
+			}
+			if alreadyDrawn != racers-1 {
+				t.Fatalf("SaveDraw: got %d ErrAlreadyDrawn, want %d", alreadyDrawn, racers-1)
+			}
+
+			saved, err := s.GetGroup(g.ID)
+			if err != nil {
+				t.Fatalf("GetGroup: %v", err)
+			}
+			if len(saved.Tokens) != 2 {
+				t.Fatalf("GetGroup: got %d tokens, want 2 (no overwrite should have corrupted the saved draw)", len(saved.Tokens))
+			}
+		})
+	}
+}
+
+func TestSaveDrawErrAlreadyDrawn(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			g, err := s.CreateGroup(Group{Participants: []string{"alice", "bob"}})
+			if err != nil {
+				t.Fatalf("CreateGroup: %v", err)
+			}
+
+			first := map[string]string{"alice": "bob", "bob": "alice"}
+			if err := s.SaveDraw(g.ID, first, map[string]string{"alice": "t1", "bob": "t2"}); err != nil {
+				t.Fatalf("SaveDraw (first): %v", err)
+			}
+
+			second := map[string]string{"alice": "bob", "bob": "alice"}
+			err = s.SaveDraw(g.ID, second, map[string]string{"alice": "t3", "bob": "t4"})
+			if !errors.Is(err, ErrAlreadyDrawn) {
+				t.Fatalf("SaveDraw (second): got %v, want ErrAlreadyDrawn", err)
+			}
+
+			saved, err := s.GetGroup(g.ID)
+			if err != nil {
+				t.Fatalf("GetGroup: %v", err)
+			}
+			if saved.Tokens["alice"] != "t1" {
+				t.Fatalf("GetGroup: token was overwritten by the losing SaveDraw call")
+			}
+		})
+	}
+}
@@ -3,28 +3,32 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"os"
-	"time"
-)
+	"path/filepath"
+	"strings"
 
-// Exclusion defines a rule where a Giver cannot give a gift to a Receiver.
-type Exclusion struct {
-	Giver    string `json:"giver"`
-	Receiver string `json:"receiver"`
-}
+	"gopkg.in/yaml.v3"
 
-// Config holds the full structure of the JSON config file.
+	"github.com/d3spis3d/unsecret-santa/internal/commit"
+	"github.com/d3spis3d/unsecret-santa/internal/locale"
+	"github.com/d3spis3d/unsecret-santa/internal/notify"
+	"github.com/d3spis3d/unsecret-santa/internal/pairing"
+	"github.com/d3spis3d/unsecret-santa/internal/server"
+	"github.com/d3spis3d/unsecret-santa/internal/store"
+)
+
+// Config holds the full structure of the config file. It accepts either
+// JSON or YAML, detected by file extension.
 type Config struct {
-	Participants []string    `json:"participants"`
-	Exclusions   []Exclusion `json:"exclusions"`
-}
+	Participants    []string `json:"participants" yaml:"participants"`
+	pairing.RuleSet `yaml:",inline"`
 
-// Pairing holds all {giver: receiver} pairings for a single valid solution.
-type Pairing map[string]string
+	Notifications *notify.SMTPConfig `json:"notifications" yaml:"notifications"`
+}
 
-// loadConfigFromFile opens, reads, and parses the JSON config file.
-// It now returns a single Config struct containing both participants and exclusions.
+// loadConfigFromFile opens, reads, and parses the config file. Files
+// ending in .yaml or .yml are parsed as YAML; everything else is
+// treated as JSON.
 func loadConfigFromFile(filename string) (Config, error) {
 	var config Config // Initialize an empty config struct
 
@@ -34,127 +38,223 @@ func loadConfigFromFile(filename string) (Config, error) {
 		return config, fmt.Errorf("could not read file %s: %w", filename, err)
 	}
 
-	// 2. Unmarshal the JSON data into our Config struct
-	if err := json.Unmarshal(byteValue, &config); err != nil {
-		return config, fmt.Errorf("could not parse JSON in %s: %w", filename, err)
+	// 2. Unmarshal into our Config struct, by extension
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(byteValue, &config); err != nil {
+			return config, fmt.Errorf("could not parse YAML in %s: %w", filename, err)
+		}
+	default:
+		if err := json.Unmarshal(byteValue, &config); err != nil {
+			return config, fmt.Errorf("could not parse JSON in %s: %w", filename, err)
+		}
 	}
 
 	return config, nil
 }
 
-// RunSecretSanta orchestrates the process.
-// (No changes to this function)
-func RunSecretSanta(participants []string, exclusions []Exclusion) {
-	// 1. SETUP
-	exclusionMap := createExclusionMap(participants, exclusions)
-	allValidPairings := make([]Pairing, 0)
-	currentPairing := make(Pairing)
-	availableReceivers := make(map[string]bool)
-	for _, p := range participants {
-		availableReceivers[p] = true
-	}
-
-	// 2. SOLVE
-	findPairingsRecursive(participants, 0, currentPairing, availableReceivers, exclusionMap, &allValidPairings)
-
-	// 3. PRINT COUNT
-	count := len(allValidPairings)
-	fmt.Printf("Found %d possible unique pairings.\n", count)
-
-	// 4. SELECT AND PRINT ONE PAIRING
-	if count > 0 {
-		rand.Seed(time.Now().UnixNano())
-		randomIndex := rand.Intn(count)
-		selectedPairing := allValidPairings[randomIndex]
-
-		fmt.Println("\n--- Selected Pairing ---")
-		for _, giver := range participants {
-			receiver := selectedPairing[giver]
-			fmt.Printf("%s 🎁 --> %s\n", giver, receiver)
+// runServe starts the HTTP API, backed by an in-memory store unless
+// --db points it at a SQLite file. --base-url is prepended to the
+// one-time pairing links emailed to participants after a draw.
+func runServe(args []string) error {
+	dbPath := ""
+	baseURL := ""
+	for i, arg := range args {
+		switch arg {
+		case "--db":
+			if i+1 < len(args) {
+				dbPath = args[i+1]
+			}
+		case "--base-url":
+			if i+1 < len(args) {
+				baseURL = args[i+1]
+			}
 		}
-	} else {
-		fmt.Println("No valid pairings could be found with these rules!")
 	}
-}
 
-// findPairingsRecursive is the core backtracking algorithm.
-// (No changes to this function)
-func findPairingsRecursive(
-	allGivers []string,
-	giverIndex int,
-	currentPairing Pairing,
-	availableReceivers map[string]bool,
-	exclusionMap map[string]map[string]bool,
-	allValidPairings *[]Pairing,
-) {
-	if giverIndex == len(allGivers) {
-		solutionCopy := make(Pairing)
-		for k, v := range currentPairing {
-			solutionCopy[k] = v
+	var s store.Store
+	if dbPath != "" {
+		sqliteStore, err := store.NewSQLiteStore(dbPath)
+		if err != nil {
+			return err
 		}
-		*allValidPairings = append(*allValidPairings, solutionCopy)
-		return
+		s = sqliteStore
+	} else {
+		s = store.NewMemoryStore()
 	}
 
-	currentGiver := allGivers[giverIndex]
-	forbiddenReceivers := exclusionMap[currentGiver]
-
-	for potentialReceiver, isAvailable := range availableReceivers {
-		if !isAvailable {
-			continue
-		}
-
-		isSelf := (currentGiver == potentialReceiver)
-		isExcluded := forbiddenReceivers[potentialReceiver]
-
-		if !isSelf && !isExcluded {
-			currentPairing[currentGiver] = potentialReceiver
-			availableReceivers[potentialReceiver] = false
-
-			findPairingsRecursive(allGivers, giverIndex+1, currentPairing, availableReceivers, exclusionMap, allValidPairings)
+	return server.New(s, baseURL).Router().Run()
+}
 
-			availableReceivers[potentialReceiver] = true
-			delete(currentPairing, currentGiver)
-		}
+// runVerify checks that a giver's sealed envelope is consistent with the
+// published commitments file.
+func runVerify(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: verify <commitments.json> <giver.txt>")
 	}
-}
 
-// createExclusionMap builds a map for fast O(1) lookups.
-// (No changes to this function)
-func createExclusionMap(participants []string, exclusions []Exclusion) map[string]map[string]bool {
-	exMap := make(map[string]map[string]bool)
-	for _, p := range participants {
-		exMap[p] = make(map[string]bool)
+	ok, err := commit.Verify(args[0], args[1])
+	if err != nil {
+		return err
 	}
-	for _, ex := range exclusions {
-		if _, ok := exMap[ex.Giver]; ok {
-			exMap[ex.Giver][ex.Receiver] = true
-		}
+	if !ok {
+		return fmt.Errorf("envelope does not match the published commitment")
 	}
-	return exMap
+
+	fmt.Println(locale.T("EnvelopeMatches", nil))
+	return nil
 }
 
 // --- Main function to run the example ---
 func main() {
 	// --- 1. Get filename from command-line arguments ---
 	if len(os.Args) < 2 {
-		fmt.Println("Error: Please provide the config JSON file as an argument.")
-		fmt.Println("Usage: go run . config.json")
+		fmt.Println(locale.T("UsageMissingConfig", nil))
+		fmt.Println(locale.T("UsageBanner", nil))
 		os.Exit(1)
 	}
+
+	if os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:]); err != nil {
+			fmt.Println(locale.T("ErrorRunningServer", map[string]interface{}{"Error": err}))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if os.Args[1] == "verify" {
+		if err := runVerify(os.Args[2:]); err != nil {
+			fmt.Println(locale.T("ErrorVerifyingEnvelope", map[string]interface{}{"Error": err}))
+			os.Exit(1)
+		}
+		return
+	}
+
 	filename := os.Args[1]
+	dryRun := false
+	seal := false
+	countOnly := false
+	lang := ""
+	commitMode := false
+	commitDir := "."
+	for i, arg := range os.Args[2:] {
+		switch arg {
+		case "--dry-run":
+			dryRun = true
+		case "--seal":
+			seal = true
+		case "--count-only":
+			countOnly = true
+		case "--commit":
+			commitMode = true
+		case "--lang":
+			if i+3 < len(os.Args) {
+				lang = os.Args[i+3]
+			}
+		case "--commit-dir":
+			if i+3 < len(os.Args) {
+				commitDir = os.Args[i+3]
+			}
+		}
+	}
+	locale.SetLang(lang)
 
 	// --- 2. Load Config (participants AND exclusions) ---
 	config, err := loadConfigFromFile(filename)
 	if err != nil {
-		fmt.Printf("Error loading config file: %v\n", err)
+		fmt.Println(locale.T("ErrorLoadingConfig", map[string]interface{}{"Error": err}))
 		os.Exit(1)
 	}
 
-	fmt.Printf("Loaded %d participants and %d exclusion rules from %s\n\n",
-		len(config.Participants), len(config.Exclusions), filename)
+	fmt.Println(locale.T("LoadedParticipants", map[string]interface{}{
+		"Count":          len(config.Participants),
+		"ExclusionCount": len(config.Exclusions),
+		"Filename":       filename,
+	}))
+	fmt.Println()
+
+	if countOnly {
+		count, err := pairing.Count(config.Participants, config.RuleSet)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(locale.T("FoundPairings", map[string]interface{}{"Count": count}))
+		return
+	}
 
-	// --- 3. Run the generator! ---
-	// Pass the loaded data directly to the function.
-	RunSecretSanta(config.Participants, config.Exclusions)
+	// --- 3. Run the generator ---
+	selectedPairing, count, err := pairing.DrawWithRules(config.Participants, config.RuleSet)
+	if err != nil {
+		fmt.Println(locale.T("NoValidPairings", nil))
+		os.Exit(1)
+	}
+	if count >= 0 {
+		fmt.Println(locale.T("FoundPairings", map[string]interface{}{"Count": count}))
+	}
+
+	if commitMode {
+		if _, err := commit.Seal(config.Participants, config.RuleSet, selectedPairing, commitDir); err != nil {
+			fmt.Println(locale.T("ErrorSealingCommitments", map[string]interface{}{"Error": err}))
+			os.Exit(1)
+		}
+		fmt.Println(locale.T("WroteCommitments", map[string]interface{}{"Dir": commitDir}))
+		return
+	}
+
+	// --- 4. Deliver (or print) the result ---
+	if config.Notifications == nil {
+		fmt.Println()
+		fmt.Println(locale.T("SelectedPairingHeader", nil))
+		for _, giver := range config.Participants {
+			fmt.Println(locale.T("PairingLine", map[string]interface{}{
+				"Giver":    giver,
+				"Receiver": selectedPairing[giver],
+			}))
+		}
+		return
+	}
+
+	notifier, err := notify.NewSMTPNotifier(*config.Notifications)
+	if err != nil {
+		fmt.Println(locale.T("ErrorSettingUpNotifications", map[string]interface{}{"Error": err}))
+		os.Exit(1)
+	}
+
+	if dryRun {
+		fmt.Println(locale.T("DryRunHeader", nil))
+		for _, giver := range config.Participants {
+			fmt.Println(locale.T("DryRunLine", map[string]interface{}{
+				"Giver":   giver,
+				"Address": notifier.Addresses()[giver],
+			}))
+		}
+		return
+	}
+
+	if err := deliver(config.Participants, selectedPairing, notifier, seal); err != nil {
+		fmt.Println(locale.T("ErrorDeliveringPairings", map[string]interface{}{"Error": err}))
+		os.Exit(1)
+	}
+	fmt.Println(locale.T("AllDelivered", nil))
+}
+
+// deliver emails each giver their assignment via notifier. When seal is
+// true, delivery must succeed for every participant or the whole draw is
+// aborted; otherwise individual failures are reported but do not stop
+// the remaining deliveries.
+func deliver(participants []string, selectedPairing pairing.Pairing, notifier notify.Notifier, seal bool) error {
+	var failed []string
+	for _, giver := range participants {
+		if err := notifier.Notify(giver, selectedPairing[giver]); err != nil {
+			if seal {
+				return fmt.Errorf("sealed draw aborted: %w", err)
+			}
+			failed = append(failed, giver)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to notify: %v", failed)
+	}
+	return nil
 }